@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/QuantumNous/new-api/service"
+)
+
+var rateLimitUsageWindows = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+}
+
+// GetRateLimitUsage handles GET /api/ratelimit/usage?window=5m&p=1&page_size=20,
+// returning the most-saturated channel×model pairs observed within window.
+func GetRateLimitUsage(c *gin.Context) {
+	window, ok := rateLimitUsageWindows[c.DefaultQuery("window", "5m")]
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "window must be one of 1m, 5m, 1h, 24h",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("p", "1"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	rows, total := service.GetTopSaturatedChannels(window, pageSize, (page-1)*pageSize)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"items": rows,
+			"total": total,
+			"page":  page,
+		},
+	})
+}
+
+// MetricsHandler exposes the Grafana-friendly Prometheus exposition format
+// (channel_rpm_used, channel_tpm_used, channel_rpd_used,
+// channel_ratelimit_rejections_total, ...) for GET /metrics.
+func MetricsHandler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/QuantumNous/new-api/service"
+)
+
+// FrontendRateLimit throttles requests by API token (falling back to client
+// IP) before they reach any handler. Routes listed in
+// service.SetFrontendRateLimitConfig's EndpointOverrides carry a stricter cap
+// than defaultMaxPerSecond.
+func FrontendRateLimit(defaultMaxPerSecond int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := frontendRateLimitKey(c)
+		origin := c.GetHeader("Origin")
+		userAgent := c.GetHeader("User-Agent")
+		maxPerSecond := service.EndpointMaxPerSecond(c.FullPath(), defaultMaxPerSecond)
+
+		allowed, err := service.CheckFrontendRateLimit(c.Request.Context(), key, origin, userAgent, maxPerSecond)
+		if err != nil {
+			// Fail open: a rate-limit store outage shouldn't take the API down.
+			c.Next()
+			return
+		}
+		if !allowed {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"success": false,
+				"message": "请求过于频繁，请稍后再试",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+func frontendRateLimitKey(c *gin.Context) string {
+	if token := c.GetHeader("Authorization"); token != "" {
+		sum := sha256.Sum256([]byte(token))
+		return "token:" + hex.EncodeToString(sum[:])
+	}
+	return "ip:" + c.ClientIP()
+}
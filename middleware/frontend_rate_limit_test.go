@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestFrontendRateLimitKeyDoesNotLeakToken guards against the bearer
+// token/API key ending up verbatim in the rate-limit store key (and thus in
+// Redis/LevelDB key-space introspection or /metrics).
+func TestFrontendRateLimitKeyDoesNotLeakToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	token := "sk-super-secret-api-key"
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", token)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+
+	key := frontendRateLimitKey(c)
+	if strings.Contains(key, token) {
+		t.Fatalf("frontendRateLimitKey(%q) = %q, must not contain the raw token", token, key)
+	}
+	if !strings.HasPrefix(key, "token:") {
+		t.Fatalf("frontendRateLimitKey(%q) = %q, want token: prefix", token, key)
+	}
+}
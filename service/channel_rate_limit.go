@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
 	"sync"
 	"time"
 
@@ -10,37 +12,235 @@ import (
 )
 
 const (
-	ChannelRPMPrefix = "channel:rpm:"
-	ChannelTPMPrefix = "channel:tpm:"
-	ChannelRPDPrefix = "channel:rpd:"
+	ChannelRPMPrefix  = "channel:rpm:"
+	ChannelTPMPrefix  = "channel:tpm:"
+	ChannelRPDPrefix  = "channel:rpd:"
+	ChannelGCRAPrefix = "channel:gcra:"
+)
+
+// RateLimitAlgorithm selects how a channel's requests are throttled.
+type RateLimitAlgorithm string
+
+const (
+	// RateLimitAlgorithmSlidingWindow evicts entries older than the window
+	// on every request and rejects once the window is full (the default).
+	RateLimitAlgorithmSlidingWindow RateLimitAlgorithm = "sliding_window"
+	// RateLimitAlgorithmGCRA throttles using the Generic Cell Rate Algorithm,
+	// which smooths bursts instead of allowing them up to the window edge.
+	RateLimitAlgorithmGCRA RateLimitAlgorithm = "gcra"
 )
 
 // Memory storage
 var (
-	memoryMutex    sync.RWMutex
-	memoryRPMStore = make(map[string][]int64)
-	memoryTPMStore = make(map[string]*MemoryCountItem)
-	memoryRPDStore = make(map[string]*MemoryCountItem)
+	memoryMutex     sync.RWMutex
+	memoryRPMStore  = make(map[string][]int64)
+	memoryGCRAStore = make(map[string]float64) // key -> theoretical arrival time (unix seconds)
+
+	// keyMutexes guards per-key critical sections (e.g. GCRA's read-modify-write)
+	// so concurrent reservations on different keys don't serialize behind memoryMutex.
+	keyMutexesGuard sync.Mutex
+	keyMutexes      = make(map[string]*sync.Mutex)
 )
 
-type MemoryCountItem struct {
-	Count      int64
-	Expiration int64 // Unix timestamp
+func lockForKey(key string) *sync.Mutex {
+	keyMutexesGuard.Lock()
+	defer keyMutexesGuard.Unlock()
+	m, ok := keyMutexes[key]
+	if !ok {
+		m = &sync.Mutex{}
+		keyMutexes[key] = m
+	}
+	return m
 }
 
-func CheckChannelRateLimit(channelId int, modelName string, rpm, tpm, rpd int) error {
-	if common.RedisEnabled {
-		return checkChannelRateLimitRedis(channelId, modelName, rpm, tpm, rpd)
+// reserveChannelRateLimitLua atomically evicts stale RPM entries from a sorted
+// set sliding window, applies INCRBY-with-EXPIRE-if-new to the TPM/RPD
+// counters, and leaves every counter untouched if any cap would be exceeded
+// (every cap is checked before anything is incremented). KEYS: rpmKey,
+// tpmKey, rpdKey. ARGV: now_ms, rpm, tpm, rpd, tokens.
+var reserveChannelRateLimitLua = `
+local rpm_key, tpm_key, rpd_key = KEYS[1], KEYS[2], KEYS[3]
+local now_ms = tonumber(ARGV[1])
+local rpm = tonumber(ARGV[2])
+local tpm = tonumber(ARGV[3])
+local rpd = tonumber(ARGV[4])
+local tokens = tonumber(ARGV[5])
+
+if rpm > 0 then
+  redis.call('ZREMRANGEBYSCORE', rpm_key, '-inf', now_ms - 60000)
+  local count = redis.call('ZCARD', rpm_key)
+  if count >= rpm then
+    return {0, 'rpm'}
+  end
+end
+
+if rpd > 0 then
+  local cur = tonumber(redis.call('GET', rpd_key) or '0')
+  if cur >= rpd then
+    return {0, 'rpd'}
+  end
+end
+
+if tpm > 0 and tokens > 0 then
+  local cur = tonumber(redis.call('GET', tpm_key) or '0')
+  if cur + tokens > tpm then
+    return {0, 'tpm'}
+  end
+end
+
+if rpm > 0 then
+  local seq = redis.call('INCR', rpd_key .. ':seq')
+  redis.call('EXPIRE', rpd_key .. ':seq', 60)
+  redis.call('ZADD', rpm_key, now_ms, now_ms .. '-' .. seq)
+  redis.call('EXPIRE', rpm_key, 60)
+end
+
+local rpd_val = redis.call('INCR', rpd_key)
+if rpd_val == 1 then
+  redis.call('EXPIRE', rpd_key, 86400)
+end
+
+if tokens > 0 then
+  local tpm_val = redis.call('INCRBY', tpm_key, tokens)
+  if tpm_val == tokens then
+    redis.call('EXPIRE', tpm_key, 60)
+  end
+end
+
+return {1, ''}
+`
+
+// reserveChannelRateLimitGCRALua blends a GCRA token-bucket RPM check
+// (new_tat = max(tat, now) + cost*emission_interval; allow if new_tat - now <=
+// burst*emission_interval) with the same fixed-window TPM/RPD enforcement
+// reserveChannelRateLimitLua uses, so GCRA mode smooths request bursts without
+// leaving tpm/rpd unenforced. Every cap is checked before any counter is
+// mutated. KEYS: gcraKey, tpmKey, rpdKey. ARGV: now, emission_interval, burst,
+// cost, rpm, tpm, tokens, rpd.
+var reserveChannelRateLimitGCRALua = `
+local gcra_key, tpm_key, rpd_key = KEYS[1], KEYS[2], KEYS[3]
+local now = tonumber(ARGV[1])
+local emission_interval = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+local rpm = tonumber(ARGV[5])
+local tpm = tonumber(ARGV[6])
+local tokens = tonumber(ARGV[7])
+local rpd = tonumber(ARGV[8])
+
+if rpd > 0 then
+  local cur = tonumber(redis.call('GET', rpd_key) or '0')
+  if cur >= rpd then
+    return {0, 'rpd'}
+  end
+end
+
+if tpm > 0 and tokens > 0 then
+  local cur = tonumber(redis.call('GET', tpm_key) or '0')
+  if cur + tokens > tpm then
+    return {0, 'tpm'}
+  end
+end
+
+local new_tat = nil
+if rpm > 0 then
+  local tat = tonumber(redis.call('GET', gcra_key) or '0')
+  if tat < now then
+    tat = now
+  end
+  new_tat = tat + cost * emission_interval
+  local allow_at = new_tat - burst * emission_interval
+  if allow_at > now then
+    return {0, 'rpm'}
+  end
+end
+
+if new_tat then
+  redis.call('SET', gcra_key, new_tat, 'EX', math.ceil(emission_interval * (burst + 1)) + 1)
+end
+
+local rpd_val = redis.call('INCR', rpd_key)
+if rpd_val == 1 then
+  redis.call('EXPIRE', rpd_key, 86400)
+end
+
+if tokens > 0 then
+  local tpm_val = redis.call('INCRBY', tpm_key, tokens)
+  if tpm_val == tokens then
+    redis.call('EXPIRE', tpm_key, 60)
+  end
+end
+
+return {1, ''}
+`
+
+// currentAndCapForLimitType reports the counter/cap pair relevant to an
+// emitted RateLimitEvent so observability consumers can compute saturation
+// without a second round-trip to the store.
+func currentAndCapForLimitType(channelId int, modelName, limitType string, rpm, tpm, rpd int) (current, cap int64) {
+	usageRpm, usageTpm, usageRpd := GetChannelRateLimitUsage(channelId, modelName)
+	switch limitType {
+	case "rpm":
+		return usageRpm, int64(rpm)
+	case "tpm":
+		return usageTpm, int64(tpm)
+	case "rpd":
+		return usageRpd, int64(rpd)
+	default:
+		return 0, 0
 	}
-	return checkChannelRateLimitMemory(channelId, modelName, rpm, tpm, rpd)
 }
 
-func RecordChannelRateLimit(channelId int, modelName string, rpm, tpm, rpd int, tokens int) {
-	if common.RedisEnabled {
-		recordChannelRateLimitRedis(channelId, modelName, rpm, tpm, rpd, tokens)
-		return
+// ReserveChannelRateLimit atomically checks and records a single request
+// against the channel's RPM/TPM/RPD caps in one round trip, so concurrent
+// requests can't race past a cap between a separate check and record step.
+// It's the only enforcement entry point for channel rate limits - a prior
+// check-then-record pair (CheckChannelRateLimit/RecordChannelRateLimit) was
+// removed because it stored RPM as a Redis list while this path stores it as
+// a sorted-set sliding window, and running both against the same key
+// produced WRONGTYPE errors. algorithm selects between a sliding-window
+// (default) and a GCRA (token-bucket-like) enforcement strategy for the RPM
+// dimension; tpm/rpd are enforced as fixed-window caps under both
+// algorithms.
+//
+// tpm here and the pre-flight debit ReserveTokens/CommitTokens/ReleaseTokens
+// make (see service/token_reservation.go) both account against the same
+// channel:tpm: counter. Callers that do pre-flight reservation for a
+// request (debiting the estimated prompt+max_tokens cost before the upstream
+// call goes out; the relay pipeline in this repository calls neither
+// ReserveTokens nor ReserveChannelRateLimit) own that request's tpm
+// accounting end-to-end through ReserveTokens/CommitTokens/ReleaseTokens and
+// must pass tpm=0 here, or the same tokens get double-booked against the
+// cap. Pass the real tpm here only for callers that never reserve tokens for
+// this request.
+func ReserveChannelRateLimit(channelId int, modelName string, algorithm RateLimitAlgorithm, rpm, tpm, rpd, tokens int) error {
+	start := time.Now()
+
+	var limitType string
+	var err error
+	if algorithm == RateLimitAlgorithmGCRA {
+		if common.RedisEnabled {
+			limitType, err = reserveChannelRateLimitGCRARedis(channelId, modelName, rpm, tpm, rpd, tokens)
+		} else {
+			limitType, err = reserveChannelRateLimitGCRAMemory(channelId, modelName, rpm, tpm, rpd, tokens)
+		}
+	} else if common.RedisEnabled {
+		limitType, err = reserveChannelRateLimitRedis(channelId, modelName, rpm, tpm, rpd, tokens)
+	} else {
+		limitType, err = reserveChannelRateLimitMemory(channelId, modelName, rpm, tpm, rpd, tokens)
 	}
-	recordChannelRateLimitMemory(channelId, modelName, rpm, tpm, rpd, tokens)
+
+	current, cap := currentAndCapForLimitType(channelId, modelName, limitType, rpm, tpm, rpd)
+	publishRateLimitEvent(RateLimitEvent{
+		ChannelId: channelId,
+		ModelName: modelName,
+		LimitType: limitType,
+		Allowed:   err == nil,
+		Current:   current,
+		Cap:       cap,
+		LatencyMs: time.Since(start).Milliseconds(),
+	})
+	return err
 }
 
 func GetChannelRateLimitUsage(channelId int, modelName string) (rpm, tpm, rpd int64) {
@@ -52,148 +252,237 @@ func GetChannelRateLimitUsage(channelId int, modelName string) (rpm, tpm, rpd in
 
 // Redis Implementation
 
-func checkChannelRateLimitRedis(channelId int, modelName string, rpm, tpm, rpd int) error {
+// getChannelRateLimitUsageRedis reports current usage against each cap.
+// rpm is read from the same sorted-set sliding window
+// reserveChannelRateLimitRedis maintains: stale members are pruned before
+// counting so usage reflects only the last 60s, matching what the
+// sliding-window Lua script itself would see. GCRA mode keeps its state
+// under the channel:gcra: key instead, so its requests are not counted in rpm.
+func getChannelRateLimitUsageRedis(channelId int, modelName string) (rpm, tpm, rpd int64) {
 	ctx := context.Background()
-	rdb := common.RDB
+	rdb := redisClientForStore()
+	store := currentRateLimitStore()
 
-	// RPM Check (Sliding Window using List)
-	if rpm > 0 {
-		key := fmt.Sprintf("%s%d:%s", ChannelRPMPrefix, channelId, modelName)
-		lenVal, err := rdb.LLen(ctx, key).Result()
-		if err == nil && int(lenVal) >= rpm {
-			oldTimeVal, err := rdb.LIndex(ctx, key, -1).Int64()
-			if err == nil {
-				now := time.Now().Unix()
-				if now-oldTimeVal < 60 {
-					return fmt.Errorf("当前渠道模型负载已饱和")
-				}
-			}
-		}
-	}
+	// RPM
+	rpmKey := fmt.Sprintf("%s%d:%s", ChannelRPMPrefix, channelId, modelName)
+	nowMs := time.Now().UnixMilli()
+	rdb.ZRemRangeByScore(ctx, rpmKey, "-inf", strconv.FormatInt(nowMs-60000, 10))
+	rpm, _ = rdb.ZCard(ctx, rpmKey).Result()
 
-	// RPD Check (Fixed Window 24h)
-	if rpd > 0 {
-		key := fmt.Sprintf("%s%d:%s", ChannelRPDPrefix, channelId, modelName)
-		val, err := rdb.Get(ctx, key).Int64()
-		if err == nil && val >= int64(rpd) {
-			return fmt.Errorf("当前渠道模型负载已饱和")
-		}
+	// TPM
+	tpmKey := fmt.Sprintf("%s%d:%s", ChannelTPMPrefix, channelId, modelName)
+	tpm, _ = store.Usage(ctx, tpmKey)
+
+	// RPD
+	rpdKey := fmt.Sprintf("%s%d:%s", ChannelRPDPrefix, channelId, modelName)
+	rpd, _ = store.Usage(ctx, rpdKey)
+
+	return
+}
+
+// reserveChannelRateLimitRedis returns the limit type that rejected the
+// reservation ("rpm", "tpm", or "rpd"), or "" if it was allowed.
+func reserveChannelRateLimitRedis(channelId int, modelName string, rpm, tpm, rpd, tokens int) (string, error) {
+	ctx := context.Background()
+	rdb := redisClientForStore()
+
+	rpmKey := fmt.Sprintf("%s%d:%s", ChannelRPMPrefix, channelId, modelName)
+	tpmKey := fmt.Sprintf("%s%d:%s", ChannelTPMPrefix, channelId, modelName)
+	rpdKey := fmt.Sprintf("%s%d:%s", ChannelRPDPrefix, channelId, modelName)
+
+	res, err := rdb.Eval(ctx, reserveChannelRateLimitLua,
+		[]string{rpmKey, tpmKey, rpdKey},
+		time.Now().UnixMilli(), rpm, tpm, rpd, tokens,
+	).Result()
+	if err != nil {
+		return "", fmt.Errorf("rate limit reservation failed: %w", err)
 	}
 
-	// TPM Check (Fixed Window 1m)
-	if tpm > 0 {
-		key := fmt.Sprintf("%s%d:%s", ChannelTPMPrefix, channelId, modelName)
-		val, err := rdb.Get(ctx, key).Int64()
-		if err == nil && val >= int64(tpm) {
-			return fmt.Errorf("当前渠道模型负载已饱和")
-		}
+	result, ok := res.([]interface{})
+	if !ok || len(result) < 2 {
+		return "", fmt.Errorf("rate limit reservation returned unexpected result")
+	}
+	if allowed, _ := result[0].(int64); allowed == 0 {
+		limitType, _ := result[1].(string)
+		return limitType, fmt.Errorf("当前渠道模型负载已饱和")
 	}
-	return nil
+	return "", nil
 }
 
-func recordChannelRateLimitRedis(channelId int, modelName string, rpm, tpm, rpd int, tokens int) {
+// reserveChannelRateLimitGCRARedis returns the limit type that rejected the
+// reservation ("rpm", "tpm", or "rpd"), or "" if it was allowed.
+func reserveChannelRateLimitGCRARedis(channelId int, modelName string, rpm, tpm, rpd, tokens int) (string, error) {
 	ctx := context.Background()
-	rdb := common.RDB
+	rdb := redisClientForStore()
 
-	// RPM Record
-	// Even if rpm limit is 0 (unlimited), we record up to a default limit for monitoring purposes
-	limitRPM := int64(rpm)
-	if limitRPM <= 0 {
-		limitRPM = 1000 // Default monitoring window size
+	gcraKey := fmt.Sprintf("%s%d:%s", ChannelGCRAPrefix, channelId, modelName)
+	tpmKey := fmt.Sprintf("%s%d:%s", ChannelTPMPrefix, channelId, modelName)
+	rpdKey := fmt.Sprintf("%s%d:%s", ChannelRPDPrefix, channelId, modelName)
+
+	var emissionInterval, burst float64
+	if rpm > 0 {
+		emissionInterval = 60.0 / float64(rpm)
+		burst = float64(rpm)
 	}
-	keyRPM := fmt.Sprintf("%s%d:%s", ChannelRPMPrefix, channelId, modelName)
-	rdb.LPush(ctx, keyRPM, time.Now().Unix())
-	rdb.LTrim(ctx, keyRPM, 0, limitRPM-1)
-	rdb.Expire(ctx, keyRPM, time.Minute)
-
-	// RPD Record
-	keyRPD := fmt.Sprintf("%s%d:%s", ChannelRPDPrefix, channelId, modelName)
-	val, _ := rdb.Incr(ctx, keyRPD).Result()
-	if val == 1 {
-		rdb.Expire(ctx, keyRPD, 24*time.Hour)
+	cost := 1.0
+
+	res, err := rdb.Eval(ctx, reserveChannelRateLimitGCRALua,
+		[]string{gcraKey, tpmKey, rpdKey},
+		float64(time.Now().UnixNano())/1e9, emissionInterval, burst, cost, rpm, tpm, tokens, rpd,
+	).Result()
+	if err != nil {
+		return "", fmt.Errorf("rate limit reservation failed: %w", err)
 	}
 
-	// TPM Record
-	if tokens > 0 {
-		keyTPM := fmt.Sprintf("%s%d:%s", ChannelTPMPrefix, channelId, modelName)
-		val, _ := rdb.IncrBy(ctx, keyTPM, int64(tokens)).Result()
-		if val == int64(tokens) {
-			rdb.Expire(ctx, keyTPM, time.Minute)
-		}
+	result, ok := res.([]interface{})
+	if !ok || len(result) < 2 {
+		return "", fmt.Errorf("rate limit reservation returned unexpected result")
+	}
+	if allowed, _ := result[0].(int64); allowed == 0 {
+		limitType, _ := result[1].(string)
+		return limitType, fmt.Errorf("当前渠道模型负载已饱和")
 	}
+	return "", nil
 }
 
-func getChannelRateLimitUsageRedis(channelId int, modelName string) (rpm, tpm, rpd int64) {
+// Memory Implementation
+
+// reserveChannelRateLimitMemory returns the limit type that rejected the
+// reservation ("rpm", "tpm", or "rpd"), or "" if it was allowed.
+func reserveChannelRateLimitMemory(channelId int, modelName string, rpm, tpm, rpd, tokens int) (string, error) {
+	key := fmt.Sprintf("%d:%s", channelId, modelName)
+
+	mu := lockForKey(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if limitType, err := checkChannelRateLimitMemory(channelId, modelName, rpm, tpm, rpd); err != nil {
+		return limitType, err
+	}
+	recordChannelRateLimitMemory(channelId, modelName, rpm, tpm, rpd, tokens)
+	return "", nil
+}
+
+// reserveChannelRateLimitGCRAMemory locks the same channel/model key
+// reserveChannelRateLimitMemory does, so tpm/rpd are enforced atomically
+// alongside the GCRA bucket instead of being silently bypassed in GCRA mode.
+// Returns the limit type that rejected the reservation ("rpm", "tpm", or
+// "rpd"), or "" if it was allowed.
+func reserveChannelRateLimitGCRAMemory(channelId int, modelName string, rpm, tpm, rpd, tokens int) (string, error) {
+	key := fmt.Sprintf("%d:%s", channelId, modelName)
+	gcraKey := fmt.Sprintf("%s%d:%s", ChannelGCRAPrefix, channelId, modelName)
+
+	mu := lockForKey(key)
+	mu.Lock()
+	defer mu.Unlock()
+
 	ctx := context.Background()
-	rdb := common.RDB
+	store := currentRateLimitStore()
 
-	// RPM
-	rpmKey := fmt.Sprintf("%s%d:%s", ChannelRPMPrefix, channelId, modelName)
-	rpm, _ = rdb.LLen(ctx, rpmKey).Result()
+	if rpd > 0 {
+		rpdKey := fmt.Sprintf("%s%s", ChannelRPDPrefix, key)
+		ok, err := store.Check(ctx, rpdKey, int64(rpd), 24*time.Hour)
+		if err == nil && !ok {
+			return "rpd", fmt.Errorf("当前渠道模型负载已饱和")
+		}
+	}
 
-	// TPM
-	tpmKey := fmt.Sprintf("%s%d:%s", ChannelTPMPrefix, channelId, modelName)
-	tpm, _ = rdb.Get(ctx, tpmKey).Int64()
+	if tpm > 0 && tokens > 0 {
+		tpmKey := fmt.Sprintf("%s%s", ChannelTPMPrefix, key)
+		cur, err := store.Usage(ctx, tpmKey)
+		if err == nil && cur+int64(tokens) > int64(tpm) {
+			return "tpm", fmt.Errorf("当前渠道模型负载已饱和")
+		}
+	}
 
-	// RPD
-	rpdKey := fmt.Sprintf("%s%d:%s", ChannelRPDPrefix, channelId, modelName)
-	rpd, _ = rdb.Get(ctx, rpdKey).Int64()
+	if rpm > 0 {
+		now := float64(time.Now().UnixNano()) / 1e9
+		emissionInterval := 60.0 / float64(rpm)
+		cost := 1.0
+		burst := float64(rpm)
+
+		memoryMutex.Lock()
+		tat := memoryGCRAStore[gcraKey]
+		memoryMutex.Unlock()
+		if tat < now {
+			tat = now
+		}
 
-	return
-}
+		newTat := tat + cost*emissionInterval
+		allowAt := newTat - burst*emissionInterval
+		if allowAt > now {
+			return "rpm", fmt.Errorf("当前渠道模型负载已饱和")
+		}
 
-// Memory Implementation
+		memoryMutex.Lock()
+		memoryGCRAStore[gcraKey] = math.Max(newTat, now)
+		memoryMutex.Unlock()
+	}
+
+	rpdKey := fmt.Sprintf("%s%s", ChannelRPDPrefix, key)
+	store.Record(ctx, rpdKey, 1, 24*time.Hour)
+
+	if tokens > 0 {
+		tpmKey := fmt.Sprintf("%s%s", ChannelTPMPrefix, key)
+		store.Record(ctx, tpmKey, int64(tokens), time.Minute)
+	}
+
+	return "", nil
+}
 
-func checkChannelRateLimitMemory(channelId int, modelName string, rpm, tpm, rpd int) error {
+// checkChannelRateLimitMemory returns the limit type that rejected the
+// request ("rpm", "tpm", or "rpd"), or "" if it was allowed.
+func checkChannelRateLimitMemory(channelId int, modelName string, rpm, tpm, rpd int) (string, error) {
 	now := time.Now().Unix()
 	key := fmt.Sprintf("%d:%s", channelId, modelName)
+	ctx := context.Background()
+	store := currentRateLimitStore()
 
 	memoryMutex.RLock()
-	defer memoryMutex.RUnlock()
+	timestamps := memoryRPMStore[key]
+	memoryMutex.RUnlock()
 
 	// RPM Check
 	if rpm > 0 {
-		if timestamps, ok := memoryRPMStore[key]; ok {
-			count := 0
-			for _, ts := range timestamps {
-				if now-ts < 60 {
-					count++
-				}
-			}
-			if count >= rpm {
-				return fmt.Errorf("当前渠道模型负载已饱和")
+		count := 0
+		for _, ts := range timestamps {
+			if now-ts < 60 {
+				count++
 			}
 		}
+		if count >= rpm {
+			return "rpm", fmt.Errorf("当前渠道模型负载已饱和")
+		}
 	}
 
-	// TPM Check
+	// TPM Check, delegated to the configured RateLimitStore
 	if tpm > 0 {
-		if item, ok := memoryTPMStore[key]; ok {
-			if now < item.Expiration && item.Count >= int64(tpm) {
-				return fmt.Errorf("当前渠道模型负载已饱和")
-			}
+		tpmKey := fmt.Sprintf("%s%s", ChannelTPMPrefix, key)
+		ok, err := store.Check(ctx, tpmKey, int64(tpm), time.Minute)
+		if err == nil && !ok {
+			return "tpm", fmt.Errorf("当前渠道模型负载已饱和")
 		}
 	}
 
-	// RPD Check
+	// RPD Check, delegated to the configured RateLimitStore
 	if rpd > 0 {
-		if item, ok := memoryRPDStore[key]; ok {
-			if now < item.Expiration && item.Count >= int64(rpd) {
-				return fmt.Errorf("当前渠道模型负载已饱和")
-			}
+		rpdKey := fmt.Sprintf("%s%s", ChannelRPDPrefix, key)
+		ok, err := store.Check(ctx, rpdKey, int64(rpd), 24*time.Hour)
+		if err == nil && !ok {
+			return "rpd", fmt.Errorf("当前渠道模型负载已饱和")
 		}
 	}
 
-	return nil
+	return "", nil
 }
 
 func recordChannelRateLimitMemory(channelId int, modelName string, rpm, tpm, rpd int, tokens int) {
 	now := time.Now().Unix()
 	key := fmt.Sprintf("%d:%s", channelId, modelName)
+	ctx := context.Background()
+	store := currentRateLimitStore()
 
 	memoryMutex.Lock()
-	defer memoryMutex.Unlock()
-
 	// RPM Record
 	timestamps := memoryRPMStore[key]
 	// Cleanup expired
@@ -213,57 +502,43 @@ func recordChannelRateLimitMemory(channelId int, modelName string, rpm, tpm, rpd
 		newTimestamps = newTimestamps[len(newTimestamps)-limitRPM:]
 	}
 	memoryRPMStore[key] = newTimestamps
+	memoryMutex.Unlock()
 
-	// TPM Record
+	// TPM Record, delegated to the configured RateLimitStore
 	if tokens > 0 {
-		item, ok := memoryTPMStore[key]
-		if !ok || now >= item.Expiration {
-			item = &MemoryCountItem{Count: int64(tokens), Expiration: now + 60}
-		} else {
-			item.Count += int64(tokens)
-		}
-		memoryTPMStore[key] = item
+		tpmKey := fmt.Sprintf("%s%s", ChannelTPMPrefix, key)
+		store.Record(ctx, tpmKey, int64(tokens), time.Minute)
 	}
 
-	// RPD Record
-	item, ok := memoryRPDStore[key]
-	if !ok || now >= item.Expiration {
-		item = &MemoryCountItem{Count: 1, Expiration: now + 24*3600}
-	} else {
-		item.Count++
-	}
-	memoryRPDStore[key] = item
+	// RPD Record, delegated to the configured RateLimitStore
+	rpdKey := fmt.Sprintf("%s%s", ChannelRPDPrefix, key)
+	store.Record(ctx, rpdKey, 1, 24*time.Hour)
 }
 
 func getChannelRateLimitUsageMemory(channelId int, modelName string) (rpm, tpm, rpd int64) {
 	now := time.Now().Unix()
 	key := fmt.Sprintf("%d:%s", channelId, modelName)
+	ctx := context.Background()
+	store := currentRateLimitStore()
 
 	memoryMutex.RLock()
-	defer memoryMutex.RUnlock()
+	timestamps := memoryRPMStore[key]
+	memoryMutex.RUnlock()
 
 	// RPM
-	if timestamps, ok := memoryRPMStore[key]; ok {
-		for _, ts := range timestamps {
-			if now-ts < 60 {
-				rpm++
-			}
+	for _, ts := range timestamps {
+		if now-ts < 60 {
+			rpm++
 		}
 	}
 
-	// TPM
-	if item, ok := memoryTPMStore[key]; ok {
-		if now < item.Expiration {
-			tpm = item.Count
-		}
-	}
+	// TPM, delegated to the configured RateLimitStore
+	tpmKey := fmt.Sprintf("%s%s", ChannelTPMPrefix, key)
+	tpm, _ = store.Usage(ctx, tpmKey)
 
-	// RPD
-	if item, ok := memoryRPDStore[key]; ok {
-		if now < item.Expiration {
-			rpd = item.Count
-		}
-	}
+	// RPD, delegated to the configured RateLimitStore
+	rpdKey := fmt.Sprintf("%s%s", ChannelRPDPrefix, key)
+	rpd, _ = store.Usage(ctx, rpdKey)
 
 	return
 }
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/QuantumNous/new-api/common"
+	"github.com/QuantumNous/new-api/service/ratelimitstore"
+)
+
+var (
+	rateLimitStoreMu   sync.RWMutex
+	rateLimitStore     ratelimitstore.RateLimitStore
+	rateLimitStoreOnce sync.Once
+)
+
+// ConfigureRateLimitStore selects the backend used for the channel TPM/RPD
+// fixed-window counters (see service/ratelimitstore for supported URI
+// schemes, e.g. "redis://", "cluster+redis://", "leveldb:///path"). Call
+// once during startup; if never called, a store is chosen lazily: a wrapper
+// around common.RDB when Redis is enabled, otherwise a plain in-process
+// memory store.
+func ConfigureRateLimitStore(uri string) error {
+	store, err := ratelimitstore.Open(uri)
+	if err != nil {
+		return err
+	}
+
+	rateLimitStoreMu.Lock()
+	old := rateLimitStore
+	rateLimitStore = store
+	rateLimitStoreMu.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+func currentRateLimitStore() ratelimitstore.RateLimitStore {
+	rateLimitStoreMu.RLock()
+	store := rateLimitStore
+	rateLimitStoreMu.RUnlock()
+	if store != nil {
+		return store
+	}
+
+	rateLimitStoreOnce.Do(func() {
+		rateLimitStoreMu.Lock()
+		defer rateLimitStoreMu.Unlock()
+		if rateLimitStore != nil {
+			return
+		}
+		if common.RedisEnabled && common.RDB != nil {
+			// Wrap the application's existing Redis client instead of
+			// dialing a second pool: the raw RPM/GCRA Lua scripts and the
+			// token reservation hash reach Redis through redisClientForStore,
+			// which then returns common.RDB, and they need to hit the same
+			// counters this store manages.
+			rateLimitStore = ratelimitstore.WrapRedisClient(common.RDB)
+			return
+		}
+		// Open never fails for the memory:// scheme.
+		rateLimitStore, _ = ratelimitstore.Open("memory://")
+	})
+
+	rateLimitStoreMu.RLock()
+	defer rateLimitStoreMu.RUnlock()
+	return rateLimitStore
+}
+
+// redisClientForStore returns the shared redis.UniversalClient backing the
+// configured RateLimitStore, falling back to common.RDB directly if the
+// store isn't Redis-backed (shouldn't normally happen when
+// common.RedisEnabled is true, since currentRateLimitStore defaults to
+// wrapping common.RDB in that case). Call sites that need raw Redis
+// operations the RateLimitStore interface doesn't expose (Lua scripts,
+// list/hash ops) should go through this instead of reaching for
+// common.RDB, so every Redis access in this package shares one pool.
+func redisClientForStore() redis.UniversalClient {
+	if client, ok := ratelimitstore.ClientFor(currentRateLimitStore()); ok {
+		return client
+	}
+	return common.RDB
+}
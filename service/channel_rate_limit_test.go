@@ -0,0 +1,58 @@
+package service
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestReserveChannelRateLimitGCRAMemoryEnforcesTPM guards against GCRA mode
+// silently bypassing the tpm cap: a channel in GCRA mode with an
+// effectively-unlimited rpm should still reject once tpm is exhausted.
+func TestReserveChannelRateLimitGCRAMemoryEnforcesTPM(t *testing.T) {
+	channelId := 9001
+	modelName := "gcra-tpm-test-model"
+	const tpm = 100
+
+	if _, err := reserveChannelRateLimitGCRAMemory(channelId, modelName, 0, tpm, 0, 60); err != nil {
+		t.Fatalf("first reservation under tpm cap: %v", err)
+	}
+
+	limitType, err := reserveChannelRateLimitGCRAMemory(channelId, modelName, 0, tpm, 0, 60)
+	if err == nil {
+		t.Fatalf("expected second reservation to exceed tpm=%d, got nil error", tpm)
+	}
+	if limitType != "tpm" {
+		t.Fatalf("limitType = %q, want %q", limitType, "tpm")
+	}
+}
+
+// TestReserveChannelRateLimitMemoryConcurrentRPM guards against concurrent
+// overcommit: with rpm=10 and 100 concurrent reservations, exactly 10 should
+// be allowed.
+func TestReserveChannelRateLimitMemoryConcurrentRPM(t *testing.T) {
+	channelId := 9002
+	modelName := "concurrent-rpm-test-model"
+	const rpm = 10
+	const attempts = 100
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+
+	wg.Add(attempts)
+	for i := 0; i < attempts; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := reserveChannelRateLimitMemory(channelId, modelName, rpm, 0, 0, 1); err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed != rpm {
+		t.Fatalf("allowed %d reservations, want exactly %d (rpm cap)", allowed, rpm)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FrontendRateLimitPrefix namespaces the fixed-interval buckets used by
+// CheckFrontendRateLimit in the configured RateLimitStore.
+const FrontendRateLimitPrefix = "frontend:rl:"
+
+// FrontendRateLimitConfig holds the tunables for CheckFrontendRateLimit.
+type FrontendRateLimitConfig struct {
+	// ExemptOrigins bypass enforcement entirely when they match the request's
+	// Origin header.
+	ExemptOrigins []string
+	// ExemptUserAgents bypass enforcement entirely when they match the
+	// request's User-Agent header.
+	ExemptUserAgents []string
+	// EndpointOverrides maps a route path (e.g. "/v1/chat/completions") to a
+	// stricter per-second cap than the global default.
+	EndpointOverrides map[string]int
+}
+
+var (
+	frontendRateLimitConfigMu sync.RWMutex
+	frontendRateLimitConfig   FrontendRateLimitConfig
+)
+
+// SetFrontendRateLimitConfig replaces the exempt lists and endpoint overrides
+// used by CheckFrontendRateLimit and EndpointMaxPerSecond.
+func SetFrontendRateLimitConfig(cfg FrontendRateLimitConfig) {
+	frontendRateLimitConfigMu.Lock()
+	defer frontendRateLimitConfigMu.Unlock()
+	frontendRateLimitConfig = cfg
+}
+
+func getFrontendRateLimitConfig() FrontendRateLimitConfig {
+	frontendRateLimitConfigMu.RLock()
+	defer frontendRateLimitConfigMu.RUnlock()
+	return frontendRateLimitConfig
+}
+
+// EndpointMaxPerSecond resolves the effective per-second cap for path,
+// falling back to defaultMax when no override is configured for it.
+func EndpointMaxPerSecond(path string, defaultMax int) int {
+	cfg := getFrontendRateLimitConfig()
+	if override, ok := cfg.EndpointOverrides[path]; ok {
+		return override
+	}
+	return defaultMax
+}
+
+// CheckFrontendRateLimit throttles ingress traffic identified by key
+// (typically a client IP or API token) using a fixed-interval bucket: the
+// current second is floored into a bucket id, incremented atomically in the
+// configured RateLimitStore (TTL set on first hit), and rejected once the
+// counter exceeds maxPerSecond. origin and userAgent are checked against the
+// configured exempt lists before any store access.
+func CheckFrontendRateLimit(ctx context.Context, key, origin, userAgent string, maxPerSecond int) (bool, error) {
+	cfg := getFrontendRateLimitConfig()
+	for _, exempt := range cfg.ExemptOrigins {
+		if exempt == origin {
+			return true, nil
+		}
+	}
+	for _, exempt := range cfg.ExemptUserAgents {
+		if exempt == userAgent {
+			return true, nil
+		}
+	}
+	if maxPerSecond <= 0 {
+		return true, nil
+	}
+
+	bucket := time.Now().Unix()
+	storeKey := fmt.Sprintf("%s%s:%d", FrontendRateLimitPrefix, key, bucket)
+
+	count, err := currentRateLimitStore().Record(ctx, storeKey, 1, time.Second)
+	if err != nil {
+		recordFrontendRateLimitDecision(true)
+		return true, err
+	}
+
+	allowed := count <= int64(maxPerSecond)
+	recordFrontendRateLimitDecision(allowed)
+	return allowed, nil
+}
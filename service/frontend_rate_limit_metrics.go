@@ -0,0 +1,25 @@
+package service
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// frontendRateLimitDecisions is labeled by outcome only (not by key) to keep
+// cardinality bounded regardless of how many distinct IPs/tokens are seen.
+var frontendRateLimitDecisions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "new_api_frontend_ratelimit_decisions_total",
+		Help: "Frontend ingress rate limit decisions, labeled by outcome (allowed/denied).",
+	},
+	[]string{"outcome"},
+)
+
+func init() {
+	prometheus.MustRegister(frontendRateLimitDecisions)
+}
+
+func recordFrontendRateLimitDecision(allowed bool) {
+	outcome := "denied"
+	if allowed {
+		outcome = "allowed"
+	}
+	frontendRateLimitDecisions.WithLabelValues(outcome).Inc()
+}
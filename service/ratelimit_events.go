@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimitEvent describes a single allow/deny decision made by the channel
+// rate limiter, emitted for observability and post-mortem purposes.
+type RateLimitEvent struct {
+	ChannelId int
+	ModelName string
+	// LimitType is "rpm", "tpm", or "rpd" when a cap was hit, empty when the
+	// request was allowed.
+	LimitType string
+	Allowed   bool
+	Current   int64
+	Cap       int64
+	LatencyMs int64
+	Timestamp int64 // unix millis
+}
+
+var (
+	rateLimitEventSubsMu sync.Mutex
+	rateLimitEventSubs   = make(map[chan RateLimitEvent]struct{})
+)
+
+// StreamRateLimitEvents returns a channel that receives every rate limit
+// allow/deny decision until ctx is done, at which point the channel is
+// closed and unsubscribed. The channel is buffered; a slow consumer drops
+// events rather than blocking the request path that produced them.
+func StreamRateLimitEvents(ctx context.Context) <-chan RateLimitEvent {
+	ch := make(chan RateLimitEvent, 256)
+
+	rateLimitEventSubsMu.Lock()
+	rateLimitEventSubs[ch] = struct{}{}
+	rateLimitEventSubsMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		rateLimitEventSubsMu.Lock()
+		delete(rateLimitEventSubs, ch)
+		close(ch)
+		rateLimitEventSubsMu.Unlock()
+	}()
+
+	return ch
+}
+
+func publishRateLimitEvent(event RateLimitEvent) {
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().UnixMilli()
+	}
+
+	rateLimitEventSubsMu.Lock()
+	for ch := range rateLimitEventSubs {
+		select {
+		case ch <- event:
+		default: // drop for a full/slow subscriber rather than block
+		}
+	}
+	rateLimitEventSubsMu.Unlock()
+
+	recordRateLimitHistory(event)
+	recordChannelRateLimitMetric(event)
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// rateLimitHistoryMaxLen bounds the in-memory ring GetTopSaturatedChannels
+// reads from.
+//
+// This is a single fixed-size ring shared by every channel×model pair and
+// every allowed-or-denied decision in the process, so its retention is short
+// (it wraps after rateLimitHistoryMaxLen events process-wide, which under
+// real traffic can be seconds) and process-local (a restart, or any
+// multi-instance deployment, loses/fragments history across instances). An
+// earlier version of this file also mirrored events into a per-channel×model
+// Redis Stream for longer, shared retention, but nothing ever read it back -
+// GetTopSaturatedChannels only ever queried this ring - so it was dead
+// writes on every single rate-limit decision and was removed. If cross-
+// instance or longer-than-ring-depth history is needed later, that requires
+// actually querying the stream here, not just writing to it.
+const rateLimitHistoryMaxLen = 2000
+
+var (
+	rateLimitHistoryMu   sync.Mutex
+	rateLimitHistoryRing = make([]RateLimitEvent, 0, rateLimitHistoryMaxLen)
+	rateLimitHistoryNext int
+)
+
+// recordRateLimitHistory persists event into the in-memory ring that backs
+// GetTopSaturatedChannels. See rateLimitHistoryMaxLen's doc comment for the
+// ring's retention caveats.
+func recordRateLimitHistory(event RateLimitEvent) {
+	rateLimitHistoryMu.Lock()
+	defer rateLimitHistoryMu.Unlock()
+	if len(rateLimitHistoryRing) < rateLimitHistoryMaxLen {
+		rateLimitHistoryRing = append(rateLimitHistoryRing, event)
+	} else {
+		rateLimitHistoryRing[rateLimitHistoryNext] = event
+		rateLimitHistoryNext = (rateLimitHistoryNext + 1) % rateLimitHistoryMaxLen
+	}
+}
+
+// ChannelUsageSummary is one row of a GetTopSaturatedChannels result: the
+// worst saturation observed for a channel×model pair within the window.
+type ChannelUsageSummary struct {
+	ChannelId          int     `json:"channel_id"`
+	ModelName          string  `json:"model_name"`
+	LimitType          string  `json:"limit_type"`
+	Current            int64   `json:"current"`
+	Cap                int64   `json:"cap"`
+	SaturationRatio    float64 `json:"saturation_ratio"`
+	RejectionsInWindow int     `json:"rejections_in_window"`
+}
+
+// GetTopSaturatedChannels returns, most-saturated first, the channel×model
+// pairs that hit a rate-limit cap within the last window, paginated by
+// limit/offset. Only rejected decisions carry a LimitType and a cap, so a
+// pair whose requests were all allowed does not appear, however close to
+// its caps it ran. window is capped by how far back
+// the in-memory ring this reads from actually reaches - see
+// rateLimitHistoryMaxLen's doc comment - so a window longer than the ring's
+// real depth under current traffic silently returns less history than
+// requested, not an empty/zero result.
+func GetTopSaturatedChannels(window time.Duration, limit, offset int) ([]ChannelUsageSummary, int) {
+	cutoff := time.Now().Add(-window).UnixMilli()
+
+	type key struct {
+		channelId int
+		modelName string
+	}
+	best := make(map[key]ChannelUsageSummary)
+
+	rateLimitHistoryMu.Lock()
+	events := make([]RateLimitEvent, len(rateLimitHistoryRing))
+	copy(events, rateLimitHistoryRing)
+	rateLimitHistoryMu.Unlock()
+
+	for _, e := range events {
+		if e.Timestamp < cutoff || e.LimitType == "" || e.Cap <= 0 {
+			continue
+		}
+		k := key{e.ChannelId, e.ModelName}
+		ratio := float64(e.Current) / float64(e.Cap)
+		summary, ok := best[k]
+		if !ok || ratio > summary.SaturationRatio {
+			summary = ChannelUsageSummary{
+				ChannelId:       e.ChannelId,
+				ModelName:       e.ModelName,
+				LimitType:       e.LimitType,
+				Current:         e.Current,
+				Cap:             e.Cap,
+				SaturationRatio: ratio,
+			}
+		}
+		if !e.Allowed {
+			summary.RejectionsInWindow++
+		}
+		best[k] = summary
+	}
+
+	rows := make([]ChannelUsageSummary, 0, len(best))
+	for _, v := range best {
+		rows = append(rows, v)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].SaturationRatio > rows[j].SaturationRatio
+	})
+
+	total := len(rows)
+	if offset >= total {
+		return []ChannelUsageSummary{}, total
+	}
+	end := offset + limit
+	if end > total || limit <= 0 {
+		end = total
+	}
+	return rows[offset:end], total
+}
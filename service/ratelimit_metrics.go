@@ -0,0 +1,57 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// channelRateLimitRejections is labeled by limit_type ("rpm"/"tpm"/"rpd") so
+// a Grafana dashboard can break down which cap is firing most often.
+var channelRateLimitRejections = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "channel_ratelimit_rejections_total",
+		Help: "Channel rate limit rejections, labeled by the limit type that fired.",
+	},
+	[]string{"limit_type"},
+)
+
+var (
+	channelRPMUsedGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "channel_rpm_used", Help: "Current requests-per-minute usage for a channel/model."},
+		[]string{"channel_id", "model"},
+	)
+	channelTPMUsedGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "channel_tpm_used", Help: "Current tokens-per-minute usage for a channel/model."},
+		[]string{"channel_id", "model"},
+	)
+	channelRPDUsedGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "channel_rpd_used", Help: "Current requests-per-day usage for a channel/model."},
+		[]string{"channel_id", "model"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(
+		channelRateLimitRejections,
+		channelRPMUsedGauge,
+		channelTPMUsedGauge,
+		channelRPDUsedGauge,
+	)
+}
+
+// recordChannelRateLimitMetric refreshes the Prometheus gauges/counters for
+// a channel×model pair from the event that just fired. Gauges are updated
+// eagerly on every decision rather than via a pull-time Collector, since the
+// set of channel×model pairs in play changes too often to enumerate lazily.
+func recordChannelRateLimitMetric(event RateLimitEvent) {
+	if event.LimitType != "" && !event.Allowed {
+		channelRateLimitRejections.WithLabelValues(event.LimitType).Inc()
+	}
+
+	channelID := fmt.Sprintf("%d", event.ChannelId)
+	rpm, tpm, rpd := GetChannelRateLimitUsage(event.ChannelId, event.ModelName)
+	channelRPMUsedGauge.WithLabelValues(channelID, event.ModelName).Set(float64(rpm))
+	channelTPMUsedGauge.WithLabelValues(channelID, event.ModelName).Set(float64(tpm))
+	channelRPDUsedGauge.WithLabelValues(channelID, event.ModelName).Set(float64(rpd))
+}
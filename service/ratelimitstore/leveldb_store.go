@@ -0,0 +1,181 @@
+package ratelimitstore
+
+import (
+	"context"
+	"encoding/binary"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// sharedLevelDBs reference-counts *leveldb.DB handles by path the same way
+// sharedClients does for Redis, since LevelDB only allows one open handle
+// per directory at a time.
+var (
+	sharedLevelDBsMu sync.Mutex
+	sharedLevelDBs   = make(map[string]*sharedLevelDB)
+)
+
+type sharedLevelDB struct {
+	db       *leveldb.DB
+	refCount int
+	// keysMu guards every Get-then-Put in Record against concurrent callers
+	// racing on the same key; LevelDB itself only guarantees atomicity of a
+	// single Get or Put, not a read-modify-write pair.
+	keysMu sync.Mutex
+}
+
+type levelDBStore struct {
+	path string
+	db   *leveldb.DB
+	mu   *sync.Mutex
+}
+
+func openLevelDBStore(uri string, u *url.URL) (RateLimitStore, error) {
+	path := u.Path
+	if path == "" {
+		path = u.Opaque
+	}
+
+	sharedLevelDBsMu.Lock()
+	defer sharedLevelDBsMu.Unlock()
+
+	sl, ok := sharedLevelDBs[path]
+	if !ok {
+		db, err := leveldb.OpenFile(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		sl = &sharedLevelDB{db: db, refCount: 0}
+		sharedLevelDBs[path] = sl
+	}
+	sl.refCount++
+	return &levelDBStore{path: path, db: sl.db, mu: &sl.keysMu}, nil
+}
+
+// encodeCounter packs the 16-byte value stored per key: an int64 counter
+// followed by an int64 unix-nano expiration, so TTL semantics survive a
+// backend with no native expiry.
+func encodeCounter(value int64, expiration time.Time) []byte {
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint64(buf[0:8], uint64(value))
+	binary.BigEndian.PutUint64(buf[8:16], uint64(expiration.UnixNano()))
+	return buf
+}
+
+func decodeCounter(buf []byte) (int64, time.Time) {
+	if len(buf) != 16 {
+		return 0, time.Time{}
+	}
+	value := int64(binary.BigEndian.Uint64(buf[0:8]))
+	expiration := time.Unix(0, int64(binary.BigEndian.Uint64(buf[8:16])))
+	return value, expiration
+}
+
+func (s *levelDBStore) Check(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+	buf, err := s.db.Get([]byte(key), nil)
+	if err == leveldb.ErrNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	value, expiration := decodeCounter(buf)
+	if time.Now().After(expiration) {
+		return true, nil
+	}
+	return value < limit, nil
+}
+
+func (s *levelDBStore) Record(ctx context.Context, key string, amount int64, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf, err := s.db.Get([]byte(key), nil)
+	now := time.Now()
+	var value int64
+	var expiration time.Time
+	if err == nil {
+		value, expiration = decodeCounter(buf)
+		if now.After(expiration) {
+			value, expiration = 0, now.Add(window)
+		}
+	} else if err == leveldb.ErrNotFound {
+		expiration = now.Add(window)
+	} else {
+		return 0, err
+	}
+
+	value += amount
+	if err := s.db.Put([]byte(key), encodeCounter(value, expiration), nil); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+func (s *levelDBStore) ReserveIfUnderLimit(ctx context.Context, key string, amount, limit int64, window time.Duration) (bool, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf, err := s.db.Get([]byte(key), nil)
+	now := time.Now()
+	var value int64
+	var expiration time.Time
+	if err == nil {
+		value, expiration = decodeCounter(buf)
+		if now.After(expiration) {
+			value, expiration = 0, now.Add(window)
+		}
+	} else if err == leveldb.ErrNotFound {
+		expiration = now.Add(window)
+	} else {
+		return false, 0, err
+	}
+
+	if limit > 0 && value+amount > limit {
+		return false, value, nil
+	}
+
+	value += amount
+	if err := s.db.Put([]byte(key), encodeCounter(value, expiration), nil); err != nil {
+		return false, 0, err
+	}
+	return true, value, nil
+}
+
+func (s *levelDBStore) Usage(ctx context.Context, key string) (int64, error) {
+	buf, err := s.db.Get([]byte(key), nil)
+	if err == leveldb.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	value, expiration := decodeCounter(buf)
+	if time.Now().After(expiration) {
+		return 0, nil
+	}
+	return value, nil
+}
+
+func (s *levelDBStore) Reset(ctx context.Context, key string) error {
+	return s.db.Delete([]byte(key), nil)
+}
+
+func (s *levelDBStore) Close() error {
+	sharedLevelDBsMu.Lock()
+	defer sharedLevelDBsMu.Unlock()
+
+	sl, ok := sharedLevelDBs[s.path]
+	if !ok {
+		return nil
+	}
+	sl.refCount--
+	if sl.refCount > 0 {
+		return nil
+	}
+	delete(sharedLevelDBs, s.path)
+	return sl.db.Close()
+}
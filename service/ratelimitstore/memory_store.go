@@ -0,0 +1,85 @@
+package ratelimitstore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryCounter struct {
+	value      int64
+	expiration time.Time
+}
+
+type memoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*memoryCounter
+}
+
+func openMemoryStore() RateLimitStore {
+	return &memoryStore{counters: make(map[string]*memoryCounter)}
+}
+
+func (s *memoryStore) Check(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok || time.Now().After(c.expiration) {
+		return true, nil
+	}
+	return c.value < limit, nil
+}
+
+func (s *memoryStore) Record(ctx context.Context, key string, amount int64, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	c, ok := s.counters[key]
+	if !ok || now.After(c.expiration) {
+		c = &memoryCounter{value: 0, expiration: now.Add(window)}
+		s.counters[key] = c
+	}
+	c.value += amount
+	return c.value, nil
+}
+
+func (s *memoryStore) ReserveIfUnderLimit(ctx context.Context, key string, amount, limit int64, window time.Duration) (bool, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	c, ok := s.counters[key]
+	if !ok || now.After(c.expiration) {
+		c = &memoryCounter{value: 0, expiration: now.Add(window)}
+		s.counters[key] = c
+	}
+	if limit > 0 && c.value+amount > limit {
+		return false, c.value, nil
+	}
+	c.value += amount
+	return true, c.value, nil
+}
+
+func (s *memoryStore) Usage(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok || time.Now().After(c.expiration) {
+		return 0, nil
+	}
+	return c.value, nil
+}
+
+func (s *memoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counters, key)
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
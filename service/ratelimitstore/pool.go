@@ -0,0 +1,110 @@
+package ratelimitstore
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// sharedClients keys a *redis.UniversalClient by the URI it was opened
+// from so that multiple subsystems (rate limit, cache, session) reuse the
+// same connection instead of each dialing their own pool. Reference-counted
+// so the client is closed exactly once, when the last owner releases it.
+var (
+	sharedClientsMu sync.Mutex
+	sharedClients   = make(map[string]*sharedClient)
+)
+
+type sharedClient struct {
+	client   redis.UniversalClient
+	refCount int
+}
+
+type redisMode int
+
+const (
+	redisModeSingle redisMode = iota
+	redisModeCluster
+	redisModeSentinel
+)
+
+// acquireRedisClient returns the shared redis.UniversalClient for uri,
+// dialing it on first use. Every call must be paired with releaseRedisClient.
+func acquireRedisClient(uri string, u *url.URL, mode redisMode) (redis.UniversalClient, error) {
+	sharedClientsMu.Lock()
+	defer sharedClientsMu.Unlock()
+
+	if sc, ok := sharedClients[uri]; ok {
+		sc.refCount++
+		return sc.client, nil
+	}
+
+	client, err := dialRedisClient(uri, u, mode)
+	if err != nil {
+		return nil, err
+	}
+	sharedClients[uri] = &sharedClient{client: client, refCount: 1}
+	return client, nil
+}
+
+// releaseRedisClient drops a reference to the client opened for uri, closing
+// the underlying connection once no owners remain.
+func releaseRedisClient(uri string) error {
+	sharedClientsMu.Lock()
+	defer sharedClientsMu.Unlock()
+
+	sc, ok := sharedClients[uri]
+	if !ok {
+		return nil
+	}
+	sc.refCount--
+	if sc.refCount > 0 {
+		return nil
+	}
+	delete(sharedClients, uri)
+	return sc.client.Close()
+}
+
+func dialRedisClient(uri string, u *url.URL, mode redisMode) (redis.UniversalClient, error) {
+	addrs := strings.Split(u.Host, ",")
+
+	switch mode {
+	case redisModeCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:    addrs,
+			Password: passwordFromURL(u),
+		}), nil
+	case redisModeSentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    u.Query().Get("master"),
+			SentinelAddrs: addrs,
+			Password:      passwordFromURL(u),
+		}), nil
+	default:
+		opts, err := redis.ParseURL(stripCompoundScheme(uri))
+		if err != nil {
+			return nil, err
+		}
+		return redis.NewClient(opts), nil
+	}
+}
+
+func passwordFromURL(u *url.URL) string {
+	if u.User == nil {
+		return ""
+	}
+	pw, _ := u.User.Password()
+	return pw
+}
+
+// stripCompoundScheme rewrites a cluster+redis:// or sentinel+redis:// URI
+// back to a plain redis:// one so redis.ParseURL can validate/normalize it;
+// the compound schemes only exist to select a dial mode in Open.
+func stripCompoundScheme(uri string) string {
+	if i := strings.Index(uri, "+redis"); i >= 0 {
+		return "redis" + uri[i+len("+redis"):]
+	}
+	return uri
+}
@@ -0,0 +1,125 @@
+package ratelimitstore
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// reserveIfUnderLimitLua atomically checks key's counter against limit and
+// applies the same INCRBY-with-EXPIRE-if-new increment Record would, only if
+// the increment wouldn't exceed limit. limit <= 0 means unlimited. KEYS: key.
+// ARGV: amount, limit, window_seconds.
+var reserveIfUnderLimitLua = `
+local key = KEYS[1]
+local amount = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+
+local cur = tonumber(redis.call('GET', key) or '0')
+if limit > 0 and cur + amount > limit then
+  return {0, cur}
+end
+
+local val = redis.call('INCRBY', key, amount)
+if val == amount then
+  redis.call('EXPIRE', key, window)
+end
+return {1, val}
+`
+
+type redisStore struct {
+	uri    string
+	client redis.UniversalClient
+	// external marks a store built with WrapRedisClient: the client is
+	// owned by the caller (e.g. common.RDB), so Close is a no-op instead of
+	// tearing down a connection this package didn't dial.
+	external bool
+}
+
+func openRedisStore(uri string, u *url.URL, mode redisMode) (RateLimitStore, error) {
+	client, err := acquireRedisClient(uri, u, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStore{uri: uri, client: client}, nil
+}
+
+// WrapRedisClient adapts an already-dialed redis.UniversalClient (e.g. the
+// application's existing shared client) into a RateLimitStore without
+// opening a second connection/pool for it. Close on the returned store is a
+// no-op; the caller keeps owning the client's lifecycle.
+func WrapRedisClient(client redis.UniversalClient) RateLimitStore {
+	return &redisStore{client: client, external: true}
+}
+
+// ClientFor returns the underlying redis.UniversalClient backing store, so
+// call sites that need raw Redis operations the RateLimitStore interface
+// doesn't expose (Lua scripts, list/hash ops) can reuse the same shared
+// connection instead of dialing their own. The second return value is false
+// for non-Redis-backed stores (memory, LevelDB).
+func ClientFor(store RateLimitStore) (redis.UniversalClient, bool) {
+	s, ok := store.(*redisStore)
+	if !ok {
+		return nil, false
+	}
+	return s.client, true
+}
+
+func (s *redisStore) Check(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+	val, err := s.client.Get(ctx, key).Int64()
+	if err != nil && err != redis.Nil {
+		return false, err
+	}
+	return val < limit, nil
+}
+
+func (s *redisStore) Record(ctx context.Context, key string, amount int64, window time.Duration) (int64, error) {
+	val, err := s.client.IncrBy(ctx, key, amount).Result()
+	if err != nil {
+		return 0, err
+	}
+	if val == amount {
+		s.client.Expire(ctx, key, window)
+	}
+	return val, nil
+}
+
+func (s *redisStore) ReserveIfUnderLimit(ctx context.Context, key string, amount, limit int64, window time.Duration) (bool, int64, error) {
+	res, err := s.client.Eval(ctx, reserveIfUnderLimitLua,
+		[]string{key}, amount, limit, int64(window/time.Second),
+	).Result()
+	if err != nil {
+		return false, 0, err
+	}
+
+	result, ok := res.([]interface{})
+	if !ok || len(result) < 2 {
+		return false, 0, fmt.Errorf("ratelimitstore: unexpected ReserveIfUnderLimit result")
+	}
+	allowed, _ := result[0].(int64)
+	val, _ := result[1].(int64)
+	return allowed == 1, val, nil
+}
+
+func (s *redisStore) Usage(ctx context.Context, key string) (int64, error) {
+	val, err := s.client.Get(ctx, key).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	return val, nil
+}
+
+func (s *redisStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+func (s *redisStore) Close() error {
+	if s.external {
+		return nil
+	}
+	return releaseRedisClient(s.uri)
+}
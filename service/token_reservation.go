@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/QuantumNous/new-api/common"
+)
+
+// TokenReservationPrefix namespaces the Redis hash (or in-memory map key)
+// that backs an in-flight token reservation.
+const TokenReservationPrefix = "channel:tpm:reservation:"
+
+// tokenReservationTTL bounds how long a reservation survives unresolved: if
+// the request that opened it crashes before calling CommitTokens or
+// ReleaseTokens, the reservation (and its provisional TPM debit staying
+// until the normal 1-minute TPM window rolls over) self-heals instead of
+// leaking forever.
+const tokenReservationTTL = 5 * time.Minute
+
+// TokenReservation is a provisional TPM debit against a channel/model's cap,
+// held until the request it was opened for either commits its real usage or
+// releases the hold entirely.
+type TokenReservation struct {
+	ID        string
+	ChannelId int
+	ModelName string
+	Estimated int64
+	CreatedAt int64
+	// WindowExpiresAt is when the 1-minute TPM window the debit was applied
+	// to rolls over. Once past this, the window's counter has already been
+	// replaced by a fresh one, so the original debit no longer exists to
+	// correct or refund.
+	WindowExpiresAt int64
+}
+
+var (
+	memoryReservationsMu sync.Mutex
+	memoryReservations   = make(map[string]*TokenReservation)
+)
+
+// ReserveTokens provisionally increments the channel/model's TPM counter by
+// estimatedTokens (prompt + max_tokens) before the relay pipeline dispatches
+// a streaming or non-streaming completion upstream, so concurrent in-flight
+// requests can't collectively blow past tpm before any of them finishes and
+// records real usage. tpm <= 0 means unlimited (the reservation always
+// succeeds). The relay layer should call this immediately before issuing the
+// upstream request, then resolve the returned reservation ID with
+// CommitTokens once a response completes (with its actual usage) or
+// ReleaseTokens if the request errors out first.
+//
+// ReserveTokens owns the request's tpm accounting end-to-end once called:
+// the same channel:tpm: counter also backs ReserveChannelRateLimit's own tpm
+// enforcement, so a caller that reserves tokens here for a request must pass
+// tpm=0 to ReserveChannelRateLimit for that same request, or the estimate
+// gets debited twice against the cap.
+func ReserveTokens(channelId int, modelName string, tpm, estimatedTokens int) (string, error) {
+	id, err := newReservationID()
+	if err != nil {
+		return "", err
+	}
+
+	ctx := context.Background()
+	key := fmt.Sprintf("%s%d:%s", ChannelTPMPrefix, channelId, modelName)
+	allowed, _, err := currentRateLimitStore().ReserveIfUnderLimit(ctx, key, int64(estimatedTokens), int64(tpm), time.Minute)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", fmt.Errorf("当前渠道模型负载已饱和")
+	}
+
+	now := time.Now()
+	reservation := &TokenReservation{
+		ID:              id,
+		ChannelId:       channelId,
+		ModelName:       modelName,
+		Estimated:       int64(estimatedTokens),
+		CreatedAt:       now.Unix(),
+		WindowExpiresAt: now.Add(time.Minute).Unix(),
+	}
+
+	if common.RedisEnabled {
+		if err := saveReservationRedis(reservation); err != nil {
+			// The TPM increment above already landed; undo it since there's
+			// no reservation record for the caller to resolve it through.
+			currentRateLimitStore().Record(ctx, key, -int64(estimatedTokens), time.Minute)
+			return "", err
+		}
+	} else {
+		memoryReservationsMu.Lock()
+		memoryReservations[id] = reservation
+		memoryReservationsMu.Unlock()
+		go expireMemoryReservation(id)
+	}
+
+	return id, nil
+}
+
+// CommitTokens replaces a reservation's estimated TPM debit with the actual
+// token usage once the upstream response completes, then clears the hold.
+// If the reservation's TPM window has already rolled over (the request ran
+// longer than a minute), the original debit no longer exists in any counter,
+// so there's nothing left to correct.
+func CommitTokens(reservationID string, actualTokens int) error {
+	reservation, err := takeReservation(reservationID)
+	if err != nil {
+		return err
+	}
+	if time.Now().Unix() >= reservation.WindowExpiresAt {
+		return nil
+	}
+
+	delta := int64(actualTokens) - reservation.Estimated
+	if delta == 0 {
+		return nil
+	}
+	key := fmt.Sprintf("%s%d:%s", ChannelTPMPrefix, reservation.ChannelId, reservation.ModelName)
+	_, err = currentRateLimitStore().Record(context.Background(), key, delta, time.Minute)
+	return err
+}
+
+// ReleaseTokens refunds a reservation's estimated TPM debit in full, for a
+// request that errored out before producing any usage. Like CommitTokens,
+// it's a no-op once the reservation's TPM window has already rolled over.
+func ReleaseTokens(reservationID string) error {
+	reservation, err := takeReservation(reservationID)
+	if err != nil {
+		return err
+	}
+	if time.Now().Unix() >= reservation.WindowExpiresAt {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s%d:%s", ChannelTPMPrefix, reservation.ChannelId, reservation.ModelName)
+	_, err = currentRateLimitStore().Record(context.Background(), key, -reservation.Estimated, time.Minute)
+	return err
+}
+
+func takeReservation(id string) (*TokenReservation, error) {
+	if common.RedisEnabled {
+		reservation, err := loadReservationRedis(id)
+		if err != nil {
+			return nil, err
+		}
+		deleteReservationRedis(id)
+		return reservation, nil
+	}
+
+	memoryReservationsMu.Lock()
+	defer memoryReservationsMu.Unlock()
+	reservation, ok := memoryReservations[id]
+	if !ok {
+		return nil, fmt.Errorf("rate limit reservation %s not found or expired", id)
+	}
+	delete(memoryReservations, id)
+	return reservation, nil
+}
+
+func expireMemoryReservation(id string) {
+	time.Sleep(tokenReservationTTL)
+	memoryReservationsMu.Lock()
+	delete(memoryReservations, id)
+	memoryReservationsMu.Unlock()
+}
+
+func newReservationID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func reservationRedisKey(id string) string {
+	return TokenReservationPrefix + id
+}
+
+func saveReservationRedis(reservation *TokenReservation) error {
+	ctx := context.Background()
+	key := reservationRedisKey(reservation.ID)
+	err := redisClientForStore().HSet(ctx, key, map[string]interface{}{
+		"channel_id":        reservation.ChannelId,
+		"model":             reservation.ModelName,
+		"estimated":         reservation.Estimated,
+		"created_at":        reservation.CreatedAt,
+		"window_expires_at": reservation.WindowExpiresAt,
+	}).Err()
+	if err != nil {
+		return err
+	}
+	return redisClientForStore().Expire(ctx, key, tokenReservationTTL).Err()
+}
+
+func loadReservationRedis(id string) (*TokenReservation, error) {
+	ctx := context.Background()
+	fields, err := redisClientForStore().HGetAll(ctx, reservationRedisKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("rate limit reservation %s not found or expired", id)
+	}
+
+	channelId, _ := strconv.Atoi(fields["channel_id"])
+	estimated, _ := strconv.ParseInt(fields["estimated"], 10, 64)
+	createdAt, _ := strconv.ParseInt(fields["created_at"], 10, 64)
+	windowExpiresAt, _ := strconv.ParseInt(fields["window_expires_at"], 10, 64)
+	return &TokenReservation{
+		ID:              id,
+		ChannelId:       channelId,
+		ModelName:       fields["model"],
+		Estimated:       estimated,
+		CreatedAt:       createdAt,
+		WindowExpiresAt: windowExpiresAt,
+	}, nil
+}
+
+func deleteReservationRedis(id string) {
+	redisClientForStore().Del(context.Background(), reservationRedisKey(id))
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestReserveTokensRejectsOverCap guards against overcommit:
+// concurrent reservations against a low tpm must not all be accepted once
+// their combined estimate exceeds the cap.
+func TestReserveTokensRejectsOverCap(t *testing.T) {
+	channelId := 9101
+	modelName := "reserve-tokens-cap-test-model"
+	const tpm = 10000
+	const estimatedPerRequest = 200
+	const attempts = 100 // 100 * 200 = 20000, 2x the cap
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+
+	wg.Add(attempts)
+	for i := 0; i < attempts; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := ReserveTokens(channelId, modelName, tpm, estimatedPerRequest); err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	maxAccepted := tpm / estimatedPerRequest
+	if accepted > maxAccepted {
+		t.Fatalf("accepted %d reservations of %d tokens each (%d total) against tpm=%d, want at most %d",
+			accepted, estimatedPerRequest, accepted*estimatedPerRequest, tpm, maxAccepted)
+	}
+}
+
+// TestReserveTokensUnlimited ensures tpm <= 0 still allows reservations.
+func TestReserveTokensUnlimited(t *testing.T) {
+	id, err := ReserveTokens(9102, "reserve-tokens-unlimited-test-model", 0, 1_000_000)
+	if err != nil {
+		t.Fatalf("ReserveTokens with tpm<=0: %v", err)
+	}
+	if id == "" {
+		t.Fatal("expected a non-empty reservation ID")
+	}
+}